@@ -0,0 +1,30 @@
+package config
+
+// Config 应用运行时配置
+type Config struct {
+	// AppID 微信公众号 AppID
+	AppID string `json:"app_id" mapstructure:"app_id"`
+	// AppSecret 微信公众号 AppSecret
+	AppSecret string `json:"app_secret" mapstructure:"app_secret"`
+
+	// CompressImages 是否在上传前压缩图片
+	CompressImages bool `json:"compress_images" mapstructure:"compress_images"`
+	// MaxImageWidth 压缩时允许的最大宽度（像素）
+	MaxImageWidth int `json:"max_image_width" mapstructure:"max_image_width"`
+	// MaxImageSize 压缩时允许的最大体积（字节）
+	MaxImageSize int `json:"max_image_size" mapstructure:"max_image_size"`
+
+	// UploadCachePath 上传结果缓存文件路径，留空表示关闭缓存
+	UploadCachePath string `json:"upload_cache_path" mapstructure:"upload_cache_path"`
+
+	// TinifyKey TinyPNG (Tinify) API Key，留空表示不启用该压缩后端
+	TinifyKey string `json:"tinify_key" mapstructure:"tinify_key"`
+	// ShortPixelKey ShortPixel API Key，留空表示不启用该压缩后端
+	ShortPixelKey string `json:"short_pixel_key" mapstructure:"short_pixel_key"`
+	// CompressorOrder 压缩后端的尝试顺序，可选值："tinify"、"shortpixel"、"local"
+	// 留空时默认为 ["tinify", "shortpixel", "local"]
+	CompressorOrder []string `json:"compressor_order" mapstructure:"compressor_order"`
+
+	// UploadRateLimit 每个主机每秒允许的上传/下载请求数，<= 0 表示不限流
+	UploadRateLimit float64 `json:"upload_rate_limit" mapstructure:"upload_rate_limit"`
+}
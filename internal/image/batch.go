@@ -0,0 +1,83 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// BatchItem 批量上传的单个输入，Path 与 URL 二选一
+type BatchItem struct {
+	Path string // 本地文件路径
+	URL  string // 在线图片地址
+}
+
+// BatchResult 批量上传中单个输入对应的结果，与 BatchItem 按下标一一对应
+type BatchResult struct {
+	Item   BatchItem
+	Result *UploadResult
+	Err    error
+}
+
+// UploadBatch 并发上传一批图片，concurrency <= 0 时默认为 runtime.NumCPU()。
+// 结果按 items 的顺序返回，不因并发执行而错位；ctx 取消后尚未开始的条目会
+// 直接以 ctx.Err() 返回，已在执行中的条目不会被中途打断
+func (p *Processor) UploadBatch(ctx context.Context, items []BatchItem, concurrency int) []BatchResult {
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	results := make([]BatchResult, len(items))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, item := range items {
+		i, item := i, item
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				results[i] = BatchResult{Item: item, Err: ctx.Err()}
+				return
+			}
+			defer func() { <-sem }()
+
+			if ctx.Err() != nil {
+				results[i] = BatchResult{Item: item, Err: ctx.Err()}
+				return
+			}
+
+			result, err := p.uploadBatchItem(ctx, item)
+			results[i] = BatchResult{Item: item, Result: result, Err: err}
+		}()
+	}
+
+	wg.Wait()
+	return results
+}
+
+// uploadBatchItem 分派单个条目并在命中微信素材接口前做限流等待
+func (p *Processor) uploadBatchItem(ctx context.Context, item BatchItem) (*UploadResult, error) {
+	switch {
+	case item.Path != "":
+		if err := p.rateLimiter.wait(ctx, wechatUploadHost); err != nil {
+			return nil, err
+		}
+		return p.UploadLocalImage(item.Path)
+	case item.URL != "":
+		if err := p.rateLimiter.wait(ctx, hostOf(item.URL)); err != nil {
+			return nil, err
+		}
+		if err := p.rateLimiter.wait(ctx, wechatUploadHost); err != nil {
+			return nil, err
+		}
+		return p.DownloadAndUpload(item.URL)
+	default:
+		return nil, fmt.Errorf("batch item must have either Path or URL set")
+	}
+}
@@ -0,0 +1,67 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestUploadBatch_PreservesOrder(t *testing.T) {
+	p := &Processor{log: zap.NewNop(), rateLimiter: newHostRateLimiter(0)}
+
+	items := make([]BatchItem, 20)
+	for i := range items {
+		if i%2 == 0 {
+			items[i] = BatchItem{Path: fmt.Sprintf("missing-%d.jpg", i)} // file does not exist -> fast, deterministic error naming the path
+		} else {
+			items[i] = BatchItem{} // neither Path nor URL set -> fast, deterministic error
+		}
+	}
+
+	results := p.UploadBatch(context.Background(), items, 4)
+	if len(results) != len(items) {
+		t.Fatalf("expected %d results, got %d", len(items), len(results))
+	}
+	for i, r := range results {
+		if r.Item != items[i] {
+			t.Fatalf("item %d: result carries item %+v, want %+v", i, r.Item, items[i])
+		}
+		if r.Err == nil {
+			t.Fatalf("item %d: expected an error", i)
+		}
+		if items[i].Path != "" && !strings.Contains(r.Err.Error(), items[i].Path) {
+			t.Fatalf("item %d: expected error for %s, got %v", i, items[i].Path, r.Err)
+		}
+	}
+}
+
+func TestUploadBatch_RespectsCancelledContext(t *testing.T) {
+	p := &Processor{rateLimiter: newHostRateLimiter(0)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	items := []BatchItem{{Path: "a.jpg"}, {URL: "http://example.com/b.jpg"}}
+	results := p.UploadBatch(ctx, items, 1)
+
+	for i, r := range results {
+		if r.Err != context.Canceled {
+			t.Fatalf("item %d: expected context.Canceled, got %v", i, r.Err)
+		}
+	}
+}
+
+func TestHostOf(t *testing.T) {
+	cases := map[string]string{
+		"https://example.com/a.jpg": "example.com",
+		"not a url %%":              "not a url %%",
+	}
+	for input, want := range cases {
+		if got := hostOf(input); got != want {
+			t.Errorf("hostOf(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package image
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// cacheValidityWindow 缓存有效期，对齐微信素材的有效期，超过后需要重新上传
+const cacheValidityWindow = 3 * 24 * time.Hour
+
+// cacheEntry 缓存条目
+type cacheEntry struct {
+	Result     UploadResult `json:"result"`
+	UploadedAt time.Time    `json:"uploaded_at"`
+}
+
+// uploadCache 基于文件内容 SHA-256 的上传结果缓存，避免重复上传相同图片
+type uploadCache struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]cacheEntry
+}
+
+// newUploadCache 加载（或创建）位于 path 的缓存文件
+func newUploadCache(path string) (*uploadCache, error) {
+	c := &uploadCache{
+		path:    path,
+		entries: make(map[string]cacheEntry),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return nil, err
+	}
+	if len(data) == 0 {
+		return c, nil
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// get 查询缓存，过期或不存在的条目视为未命中
+func (c *uploadCache) get(sha string) (UploadResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[sha]
+	if !ok {
+		return UploadResult{}, false
+	}
+	if time.Since(entry.UploadedAt) > cacheValidityWindow {
+		return UploadResult{}, false
+	}
+	return entry.Result, true
+}
+
+// put 写入缓存并落盘
+func (c *uploadCache) put(sha string, result UploadResult) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[sha] = cacheEntry{Result: result, UploadedAt: time.Now()}
+	return c.saveLocked()
+}
+
+// delete 移除指定条目
+func (c *uploadCache) delete(sha string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[sha]; !ok {
+		return nil
+	}
+	delete(c.entries, sha)
+	return c.saveLocked()
+}
+
+// purgeExpired 清理所有已过期的条目，返回清理的数量
+func (c *uploadCache) purgeExpired() (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for sha, entry := range c.entries {
+		if time.Since(entry.UploadedAt) > cacheValidityWindow {
+			delete(c.entries, sha)
+			removed++
+		}
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, c.saveLocked()
+}
+
+// saveLocked 原子写入缓存文件，调用方需持有 mu
+func (c *uploadCache) saveLocked() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// sha256File 计算文件内容的 SHA-256，十六进制表示
+func sha256File(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// sha256Bytes 计算内存中字节切片的 SHA-256，十六进制表示
+func sha256Bytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
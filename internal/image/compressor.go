@@ -0,0 +1,214 @@
+package image
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+	"github.com/nfnt/resize"
+	"go.uber.org/zap"
+)
+
+// defaultCompressorOrder 未在配置中指定时的默认尝试顺序：
+// 优先使用外部服务获得更好的压缩率，失败或未配置 Key 时回退到本地编码器
+var defaultCompressorOrder = []string{"tinify", "shortpixel", "local"}
+
+// Backend 压缩后端，Compressor 按顺序尝试每个后端直至其中一个压缩成功。
+// 所有后端都直接处理内存中的字节，便于在磁盘文件和流式场景下复用同一套实现
+type Backend interface {
+	// Name 返回后端标识，用于日志和 CompressorOrder 配置匹配
+	Name() string
+	// Compress 尝试将 data 压缩到指定的最大宽度与体积以内，
+	// 返回压缩后的字节、是否实际减小了体积，以及遇到的错误
+	Compress(data []byte, maxWidth, maxSize int) ([]byte, bool, error)
+}
+
+// Compressor 按配置顺序依次尝试多个压缩后端的压缩器
+type Compressor struct {
+	log      *zap.Logger
+	maxWidth int
+	maxSize  int
+	backends []Backend
+	local    *localBackend
+}
+
+// NewCompressor 根据配置构建压缩后端链
+func NewCompressor(log *zap.Logger, cfg *config.Config) *Compressor {
+	local := &localBackend{log: log, quality: 85}
+
+	available := map[string]Backend{
+		"local": local,
+	}
+	if cfg.TinifyKey != "" {
+		available["tinify"] = newTinifyBackend(cfg.TinifyKey, log)
+	}
+	if cfg.ShortPixelKey != "" {
+		available["shortpixel"] = newShortPixelBackend(cfg.ShortPixelKey, log)
+	}
+
+	order := cfg.CompressorOrder
+	if len(order) == 0 {
+		order = defaultCompressorOrder
+	}
+
+	var backends []Backend
+	for _, name := range order {
+		if backend, ok := available[name]; ok {
+			backends = append(backends, backend)
+		}
+	}
+	// local 编码器始终兜底，即使未出现在配置的顺序中
+	if _, ok := available["local"]; ok {
+		hasLocal := false
+		for _, b := range backends {
+			if b.Name() == "local" {
+				hasLocal = true
+				break
+			}
+		}
+		if !hasLocal {
+			backends = append(backends, local)
+		}
+	}
+
+	return &Compressor{
+		log:      log,
+		maxWidth: cfg.MaxImageWidth,
+		maxSize:  cfg.MaxImageSize,
+		backends: backends,
+		local:    local,
+	}
+}
+
+// CompressImage 读取 filePath，依次尝试各压缩后端，将压缩成功的结果写回
+// 同目录下的新文件并返回其路径；供只接受文件路径的调用方使用。
+//
+// 输出文件名以压缩结果的实际编码格式为准（而非原始扩展名）：压缩链中的
+// WebP 转码会把输入变成 JPEG/PNG，如果仍按原扩展名命名，微信素材接口会
+// 因为扩展名与实际内容不符而拒绝上传
+func (c *Compressor) CompressImage(filePath string) (string, bool, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", false, err
+	}
+
+	compressed, ok, err := c.CompressBytes(data)
+	if err != nil || !ok {
+		return filePath, false, err
+	}
+
+	ext := filepath.Ext(filePath)
+	base := strings.TrimSuffix(filePath, ext)
+	outPath := base + ".compressed" + extForImageBytes(compressed, ext)
+
+	if err := os.WriteFile(outPath, compressed, 0o644); err != nil {
+		return "", false, err
+	}
+	return outPath, true, nil
+}
+
+// CompressBytes 依次尝试各压缩后端，返回第一个压缩成功的结果；
+// 某个后端出错或跳过时不会中断整个流程，会自动尝试下一个。纯内存操作，
+// 供流式上传等无需落盘的场景使用。
+//
+// 远程后端并不了解微信不接受 WebP 这件事，压缩后仍可能是 WebP 字节，
+// 因此无论哪个后端产出了结果，最后都会强制做一次 WebP -> JPEG/PNG 的校验
+func (c *Compressor) CompressBytes(data []byte) ([]byte, bool, error) {
+	result := data
+	ok := false
+	var lastErr error
+
+	for _, backend := range c.backends {
+		out, compressed, err := backend.Compress(data, c.maxWidth, c.maxSize)
+		if err != nil {
+			c.log.Warn("compressor backend failed, trying next",
+				zap.String("backend", backend.Name()), zap.Error(err))
+			lastErr = err
+			continue
+		}
+		if compressed {
+			result, ok = out, true
+			break
+		}
+	}
+
+	if isWebPFormat(result) {
+		converted, convertedOK, err := c.local.compressWebP(result, c.maxWidth)
+		if err != nil {
+			return nil, false, err
+		}
+		if convertedOK {
+			return converted, true, nil
+		}
+	}
+
+	if !ok {
+		return nil, false, lastErr
+	}
+	return result, true, nil
+}
+
+// SetQuality 设置本地编码器的压缩质量（1-100），不影响远程后端
+func (c *Compressor) SetQuality(quality int) {
+	c.local.quality = quality
+}
+
+// localBackend 基于 nfnt/resize 与标准库 jpeg/png 编码器的本地压缩实现
+type localBackend struct {
+	log     *zap.Logger
+	quality int
+}
+
+func (b *localBackend) Name() string { return "local" }
+
+// Compress 按最大宽度等比缩放图片，并以配置的质量重新编码；
+// 若结果体积未小于原图，则视为压缩失败（compressed=false）。
+// GIF 按帧缩放以保留动画，WebP 解码后转为 JPEG/PNG 以便微信接口接受
+func (b *localBackend) Compress(data []byte, maxWidth, maxSize int) ([]byte, bool, error) {
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch format {
+	case "gif":
+		return compressGIF(data, maxWidth, maxSize)
+	case "webp":
+		return b.compressWebP(data, maxWidth)
+	}
+
+	if maxSize > 0 && len(data) <= maxSize {
+		return nil, false, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, false, err
+	}
+
+	bounds := img.Bounds()
+	if maxWidth > 0 && bounds.Dx() > maxWidth {
+		img = resize.Resize(uint(maxWidth), 0, img, resize.Lanczos3)
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	default:
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: b.quality})
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if buf.Len() >= len(data) {
+		return nil, false, nil
+	}
+	return buf.Bytes(), true, nil
+}
@@ -0,0 +1,61 @@
+package image
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"image/gif"
+
+	"github.com/nfnt/resize"
+)
+
+// compressGIF 对动图的每一帧分别按统一比例缩放后重新编码，逐帧的调色板、
+// 播放延迟（Delay）与处理方式（Disposal）保持不变，只有画面尺寸发生变化
+func compressGIF(data []byte, maxWidth, maxSize int) ([]byte, bool, error) {
+	if maxSize > 0 && len(data) <= maxSize {
+		return nil, false, nil
+	}
+
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, false, err
+	}
+
+	if maxWidth > 0 && g.Config.Width > maxWidth {
+		scale := float64(maxWidth) / float64(g.Config.Width)
+		for i, frame := range g.Image {
+			g.Image[i] = resizePalettedFrame(frame, scale)
+		}
+		g.Config.Width = int(float64(g.Config.Width) * scale)
+		g.Config.Height = int(float64(g.Config.Height) * scale)
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		return nil, false, err
+	}
+
+	if buf.Len() >= len(data) {
+		return nil, false, nil
+	}
+	return buf.Bytes(), true, nil
+}
+
+// resizePalettedFrame 按 scale 缩放单帧，保留其原有调色板与画布中的相对位置
+func resizePalettedFrame(frame *image.Paletted, scale float64) *image.Paletted {
+	bounds := frame.Bounds()
+
+	newWidth := uint(float64(bounds.Dx()) * scale)
+	if newWidth < 1 {
+		newWidth = 1
+	}
+	resized := resize.Resize(newWidth, 0, frame, resize.Lanczos3)
+
+	minX := int(float64(bounds.Min.X) * scale)
+	minY := int(float64(bounds.Min.Y) * scale)
+	rect := image.Rect(minX, minY, minX+resized.Bounds().Dx(), minY+resized.Bounds().Dy())
+
+	out := image.NewPaletted(rect, frame.Palette)
+	draw.Draw(out, out.Bounds(), resized, resized.Bounds().Min, draw.Src)
+	return out
+}
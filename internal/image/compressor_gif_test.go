@@ -0,0 +1,70 @@
+package image
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+)
+
+func newTestGIF(t *testing.T, width, height, frames int) []byte {
+	t.Helper()
+
+	palette := color.Palette{color.White, color.Black}
+	g := &gif.GIF{}
+	for i := 0; i < frames; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, width, height), palette)
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 10)
+		g.Disposal = append(g.Disposal, gif.DisposalNone)
+	}
+	g.Config = image.Config{Width: width, Height: height, ColorModel: palette}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatalf("failed to encode test gif: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestCompressGIF_PreservesFrameCountAndResizes(t *testing.T) {
+	original := newTestGIF(t, 400, 200, 3)
+
+	// force compression by giving an unreasonably small maxSize threshold
+	compressed, ok, err := compressGIF(original, 100, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatalf("expected compression to succeed")
+	}
+
+	g, err := gif.DecodeAll(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("failed to decode compressed gif: %v", err)
+	}
+	if len(g.Image) != 3 {
+		t.Fatalf("expected 3 frames to be preserved, got %d", len(g.Image))
+	}
+	if g.Image[0].Bounds().Dx() != 100 {
+		t.Fatalf("expected frame width to be scaled to 100, got %d", g.Image[0].Bounds().Dx())
+	}
+	for i, delay := range g.Delay {
+		if delay != 10 {
+			t.Errorf("frame %d: expected delay to be preserved as 10, got %d", i, delay)
+		}
+	}
+}
+
+func TestCompressGIF_SkipsWhenAlreadySmall(t *testing.T) {
+	original := newTestGIF(t, 400, 200, 1)
+
+	_, ok, err := compressGIF(original, 100, len(original)+1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok {
+		t.Fatalf("expected compression to be skipped when already under maxSize")
+	}
+}
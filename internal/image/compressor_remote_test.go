@@ -0,0 +1,98 @@
+package image
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+	"go.uber.org/zap"
+)
+
+func TestTinifyBackend_Compress(t *testing.T) {
+	compressed := []byte("small")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/shrink":
+			w.Header().Set("Location", "http://"+r.Host+"/result")
+			w.WriteHeader(http.StatusCreated)
+		case "/result":
+			_, _ = w.Write(compressed)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	original := []byte("this is a much larger original image payload than the compressed one")
+
+	backend := newTinifyBackend("fake-key", zap.NewNop())
+	backend.apiURL = server.URL + "/shrink"
+	backend.client = server.Client()
+
+	result, ok, err := backend.Compress(original, 800, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatalf("expected compression to succeed")
+	}
+	if string(result) != string(compressed) {
+		t.Fatalf("expected compressed payload %q, got %q", compressed, result)
+	}
+}
+
+func TestNewCompressor_OmitsTinifyWithoutKey(t *testing.T) {
+	c := NewCompressor(zap.NewNop(), &config.Config{CompressorOrder: []string{"tinify", "local"}})
+	for _, b := range c.backends {
+		if b.Name() == "tinify" {
+			t.Fatalf("expected tinify backend to be omitted when TinifyKey is not configured")
+		}
+	}
+}
+
+func TestShortPixelBackend_Compress(t *testing.T) {
+	compressed := []byte("sp")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/reducer":
+			resp := []shortPixelResponse{{LossyURL: "http://" + r.Host + "/result"}}
+			resp[0].Status.Code = 2
+			_ = json.NewEncoder(w).Encode(resp)
+		case "/result":
+			_, _ = w.Write(compressed)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	original := []byte("this is a much larger original image payload than the compressed one")
+
+	backend := newShortPixelBackend("fake-key", zap.NewNop())
+	backend.apiURL = server.URL + "/reducer"
+	backend.client = server.Client()
+
+	result, ok, err := backend.Compress(original, 800, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Fatalf("expected compression to succeed")
+	}
+	if string(result) != string(compressed) {
+		t.Fatalf("expected compressed payload %q, got %q", compressed, result)
+	}
+}
+
+func TestNewCompressor_OmitsShortPixelWithoutKey(t *testing.T) {
+	c := NewCompressor(zap.NewNop(), &config.Config{CompressorOrder: []string{"shortpixel", "local"}})
+	for _, b := range c.backends {
+		if b.Name() == "shortpixel" {
+			t.Fatalf("expected shortpixel backend to be omitted when ShortPixelKey is not configured")
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package image
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// shortPixelAPIURL ShortPixel 压缩接口地址
+const shortPixelAPIURL = "https://api.shortpixel.com/v2/reducer.php"
+
+// shortPixelResponse ShortPixel 接口返回的单条结果
+type shortPixelResponse struct {
+	Status struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"Status"`
+	LosslessURL string `json:"LosslessURL"`
+	LossyURL    string `json:"LossyURL"`
+}
+
+// shortPixelBackend 基于 ShortPixel API 的压缩后端
+type shortPixelBackend struct {
+	apiKey string
+	apiURL string
+	log    *zap.Logger
+	client *http.Client
+}
+
+func newShortPixelBackend(apiKey string, log *zap.Logger) *shortPixelBackend {
+	return &shortPixelBackend{
+		apiKey: apiKey,
+		apiURL: shortPixelAPIURL,
+		log:    log,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (b *shortPixelBackend) Name() string { return "shortpixel" }
+
+// Compress 将图片提交给 ShortPixel 压缩，下载返回的有损版本；请求失败时
+// 返回 error 让上层跳到下一个后端。NewCompressor 只在配置了 ShortPixelKey
+// 时才会把这个后端加入链路，因此这里不需要再检查 Key 是否为空
+func (b *shortPixelBackend) Compress(data []byte, maxWidth, maxSize int) ([]byte, bool, error) {
+	original := len(data)
+	if maxSize > 0 && original <= maxSize {
+		return nil, false, nil
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	_ = writer.WriteField("key", b.apiKey)
+	_ = writer.WriteField("lossy", "1")
+	_ = writer.WriteField("resize", "1")
+	if maxWidth > 0 {
+		_ = writer.WriteField("resize_width", fmt.Sprintf("%d", maxWidth))
+	}
+	part, err := writer.CreateFormFile("file", "image")
+	if err != nil {
+		return nil, false, err
+	}
+	if _, err := part.Write(data); err != nil {
+		return nil, false, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, false, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, b.apiURL, &body)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("shortpixel: unexpected status %d", resp.StatusCode)
+	}
+
+	var results []shortPixelResponse
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, false, err
+	}
+	if len(results) == 0 || results[0].Status.Code < 0 {
+		return nil, false, fmt.Errorf("shortpixel: compression failed")
+	}
+
+	resultURL := results[0].LossyURL
+	if resultURL == "" {
+		resultURL = results[0].LosslessURL
+	}
+	if resultURL == "" {
+		return nil, false, fmt.Errorf("shortpixel: missing result url")
+	}
+
+	compressed, err := b.download(resultURL)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(compressed) >= original {
+		return nil, false, nil
+	}
+
+	return compressed, true, nil
+}
+
+func (b *shortPixelBackend) download(url string) ([]byte, error) {
+	resp, err := b.client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("shortpixel: download failed with status %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
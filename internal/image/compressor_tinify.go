@@ -0,0 +1,88 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// tinifyAPIURL TinyPNG (Tinify) 压缩接口地址
+const tinifyAPIURL = "https://api.tinify.com/shrink"
+
+// tinifyBackend 基于 TinyPNG API 的压缩后端
+type tinifyBackend struct {
+	apiKey string
+	apiURL string
+	log    *zap.Logger
+	client *http.Client
+}
+
+func newTinifyBackend(apiKey string, log *zap.Logger) *tinifyBackend {
+	return &tinifyBackend{
+		apiKey: apiKey,
+		apiURL: tinifyAPIURL,
+		log:    log,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (b *tinifyBackend) Name() string { return "tinify" }
+
+// Compress 将图片上传至 TinyPNG，下载压缩结果；请求失败时返回 error
+// 让上层跳到下一个后端，不中断整体上传流程。NewCompressor 只在配置了
+// TinifyKey 时才会把这个后端加入链路，因此这里不需要再检查 Key 是否为空。
+// 该后端只做压缩，不按 maxWidth 缩放：maxWidth 不会传给 TinyPNG，结果保持原始尺寸
+func (b *tinifyBackend) Compress(data []byte, maxWidth, maxSize int) ([]byte, bool, error) {
+	original := len(data)
+	if maxSize > 0 && original <= maxSize {
+		return nil, false, nil
+	}
+
+	req, err := http.NewRequest(http.MethodPost, b.apiURL, bytes.NewReader(data))
+	if err != nil {
+		return nil, false, err
+	}
+	req.SetBasicAuth("api", b.apiKey)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, false, fmt.Errorf("tinify: unexpected status %d", resp.StatusCode)
+	}
+
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return nil, false, fmt.Errorf("tinify: missing result location")
+	}
+
+	compressed, err := b.download(location)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(compressed) >= original {
+		return nil, false, nil
+	}
+
+	return compressed, true, nil
+}
+
+func (b *tinifyBackend) download(location string) ([]byte, error) {
+	resp, err := b.client.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tinify: download failed with status %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
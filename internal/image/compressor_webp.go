@@ -0,0 +1,58 @@
+package image
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	chaiwebp "github.com/chai2010/webp"
+	"github.com/nfnt/resize"
+	_ "golang.org/x/image/webp"
+)
+
+// compressWebP 解码 WebP 图片、按需缩放，再重新编码为 JPEG 或 PNG。
+// 微信素材上传接口不接受 webp，因此这里的“压缩”同时承担了格式转换的职责：
+// 不透明图片转为 JPEG，带透明通道的图片转为 PNG 以保留 alpha。
+// 优先走标准库注册的 golang.org/x/image/webp（纯 Go，覆盖常见有损格式），
+// 解码失败时回退到 github.com/chai2010/webp（基于 libwebp，支持无损/高级 alpha 变体）
+func (b *localBackend) compressWebP(data []byte, maxWidth int) ([]byte, bool, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		img, err = chaiwebp.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	if maxWidth > 0 && img.Bounds().Dx() > maxWidth {
+		img = resize.Resize(uint(maxWidth), 0, img, resize.Lanczos3)
+	}
+
+	var buf bytes.Buffer
+	if hasAlpha(img) {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: b.quality})
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return buf.Bytes(), true, nil
+}
+
+// hasAlpha 判断图片是否包含透明通道
+func hasAlpha(img image.Image) bool {
+	type opaquer interface{ Opaque() bool }
+	if o, ok := img.(opaquer); ok {
+		return !o.Opaque()
+	}
+	return false
+}
+
+// isWebPFormat 嗅探字节内容是否为 WebP，用于在压缩链之后强制做格式转换
+func isWebPFormat(data []byte) bool {
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	return err == nil && format == "webp"
+}
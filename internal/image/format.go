@@ -0,0 +1,125 @@
+package image
+
+import (
+	"bytes"
+	"image"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+)
+
+// validImageExtensions 允许输入的图片格式；其中 webp 不被微信素材接口接受，
+// 上传前会先转码为 JPEG/PNG
+var validImageExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".gif":  true,
+	".bmp":  true,
+	".webp": true,
+}
+
+// IsValidImageFormat 根据扩展名判断是否为支持的图片格式
+func IsValidImageFormat(filePath string) bool {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	return validImageExtensions[ext]
+}
+
+// isWebPExt 判断文件扩展名是否为 webp。微信素材接口不接受 webp，
+// 因此即便调用方关闭了压缩，webp 也必须强制走一遍格式转换；
+// isWebPFormat（compressor_webp.go）对内存中的字节做同样的判断，供
+// 拿不到可靠文件名的场景（下载流、reader 上传）使用
+func isWebPExt(filePath string) bool {
+	return strings.ToLower(filepath.Ext(filePath)) == ".webp"
+}
+
+// IsValidImageContent 通过嗅探数据本身判断是否为可解码的图片格式。
+// 很多 CDN 用查询参数而非扩展名标记格式（例如 .../640?wx_fmt=png），
+// 这种情况下 URL 派生的文件名没有可用的扩展名，IsValidImageFormat 会
+// 误判为无效；下载类场景应在拿到数据后以此作为补充校验
+func IsValidImageContent(data []byte) bool {
+	_, _, err := image.DecodeConfig(bytes.NewReader(data))
+	return err == nil
+}
+
+// extForImageBytes 嗅探压缩/转码后的字节实际编码格式，返回对应的扩展名
+// （含前导点）。微信素材接口按扩展名校验文件类型，压缩输出的文件名必须
+// 反映真实编码格式，而不是原始输入的扩展名。无法识别时回退到 fallback
+func extForImageBytes(data []byte, fallback string) string {
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fallback
+	}
+	switch format {
+	case "jpeg":
+		return ".jpg"
+	case "png":
+		return ".png"
+	case "gif":
+		return ".gif"
+	default:
+		return fallback
+	}
+}
+
+// ImageInfo 图片基本信息
+type ImageInfo struct {
+	Width      int    `json:"width"`
+	Height     int    `json:"height"`
+	Format     string `json:"format"`
+	Size       int64  `json:"size"`
+	Frames     int    `json:"frames"`
+	IsAnimated bool   `json:"is_animated"`
+}
+
+// GetImageInfo 读取图片的尺寸、格式、文件大小，以及（对 GIF 而言）帧数信息
+func GetImageInfo(filePath string) (*ImageInfo, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.ToLower(filepath.Ext(filePath)) == ".gif" {
+		if _, err := f.Seek(0, 0); err != nil {
+			return nil, err
+		}
+		g, err := gif.DecodeAll(f)
+		if err != nil {
+			return nil, err
+		}
+		return &ImageInfo{
+			Width:      g.Config.Width,
+			Height:     g.Config.Height,
+			Format:     "gif",
+			Size:       stat.Size(),
+			Frames:     len(g.Image),
+			IsAnimated: len(g.Image) > 1,
+		}, nil
+	}
+
+	cfg, format, err := image.DecodeConfig(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ImageInfo{
+		Width:      cfg.Width,
+		Height:     cfg.Height,
+		Format:     format,
+		Size:       stat.Size(),
+		Frames:     1,
+		IsAnimated: false,
+	}, nil
+}
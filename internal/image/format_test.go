@@ -0,0 +1,56 @@
+package image
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodeTestJPEG(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("failed to encode test jpeg: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func encodeTestPNG(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	img.Set(0, 0, color.NRGBA{R: 1, G: 2, B: 3, A: 4})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode test png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtForImageBytes_MatchesActualFormat(t *testing.T) {
+	if ext := extForImageBytes(encodeTestJPEG(t), ".webp"); ext != ".jpg" {
+		t.Fatalf("expected .jpg for jpeg-encoded bytes, got %s", ext)
+	}
+	if ext := extForImageBytes(encodeTestPNG(t), ".webp"); ext != ".png" {
+		t.Fatalf("expected .png for png-encoded bytes, got %s", ext)
+	}
+}
+
+func TestExtForImageBytes_WebPConversionDoesNotKeepWebPExtension(t *testing.T) {
+	// WebP input goes through compressWebP and comes out as JPEG or PNG bytes;
+	// the output file name must follow the new format, not the original
+	// ".webp" extension, or WeChat's extension-based validation rejects it.
+	ext := extForImageBytes(encodeTestJPEG(t), ".webp")
+	if ext == ".webp" {
+		t.Fatalf("expected converted bytes to resolve to a non-webp extension, got %s", ext)
+	}
+}
+
+func TestExtForImageBytes_FallsBackWhenUnrecognized(t *testing.T) {
+	if ext := extForImageBytes([]byte("not an image"), ".jpg"); ext != ".jpg" {
+		t.Fatalf("expected fallback extension .jpg, got %s", ext)
+	}
+}
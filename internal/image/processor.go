@@ -1,8 +1,12 @@
 package image
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/geekjourneyx/md2wechat-skill/internal/config"
 	"github.com/geekjourneyx/md2wechat-skill/internal/wechat"
@@ -11,20 +15,35 @@ import (
 
 // Processor 图片处理器
 type Processor struct {
-	cfg        *config.Config
-	log        *zap.Logger
-	ws         *wechat.Service
-	compressor *Compressor
+	cfg         *config.Config
+	log         *zap.Logger
+	ws          *wechat.Service
+	compressor  *Compressor
+	cache       *uploadCache
+	rateLimiter *hostRateLimiter
 }
 
 // NewProcessor 创建图片处理器
 func NewProcessor(cfg *config.Config, log *zap.Logger) *Processor {
-	return &Processor{
-		cfg:        cfg,
-		log:        log,
-		ws:         wechat.NewService(cfg, log),
-		compressor: NewCompressor(log, cfg.MaxImageWidth, cfg.MaxImageSize),
+	p := &Processor{
+		cfg:         cfg,
+		log:         log,
+		ws:          wechat.NewService(cfg, log),
+		compressor:  NewCompressor(log, cfg),
+		rateLimiter: newHostRateLimiter(cfg.UploadRateLimit),
 	}
+
+	if cfg.UploadCachePath != "" {
+		cache, err := newUploadCache(cfg.UploadCachePath)
+		if err != nil {
+			log.Warn("failed to load upload cache, continuing without it",
+				zap.String("path", cfg.UploadCachePath), zap.Error(err))
+		} else {
+			p.cache = cache
+		}
+	}
+
+	return p
 }
 
 // UploadResult 上传结果
@@ -49,9 +68,9 @@ func (p *Processor) UploadLocalImage(filePath string) (*UploadResult, error) {
 		return nil, fmt.Errorf("unsupported image format: %s", filePath)
 	}
 
-	// 如果需要压缩，先处理
+	// 如果需要压缩，先处理；见 isWebPExt 的说明
 	processedPath := filePath
-	if p.cfg.CompressImages {
+	if p.cfg.CompressImages || isWebPExt(filePath) {
 		compressedPath, compressed, err := p.compressor.CompressImage(filePath)
 		if err != nil {
 			p.log.Warn("compress failed, using original", zap.Error(err))
@@ -62,57 +81,155 @@ func (p *Processor) UploadLocalImage(filePath string) (*UploadResult, error) {
 		}
 	}
 
+	// 命中缓存则直接复用，避免重复上传
+	sha, shaErr := sha256File(processedPath)
+	if shaErr != nil {
+		p.log.Warn("failed to hash image, skipping cache lookup", zap.Error(shaErr))
+	} else if p.cache != nil {
+		if cached, ok := p.cache.get(sha); ok {
+			p.log.Info("upload cache hit", zap.String("sha256", sha))
+			return &cached, nil
+		}
+	}
+
 	// 上传到微信
 	result, err := p.ws.UploadMaterialWithRetry(processedPath, 3)
 	if err != nil {
 		return nil, err
 	}
 
-	return &UploadResult{
+	uploadResult := &UploadResult{
 		MediaID:   result.MediaID,
 		WechatURL: result.WechatURL,
-	}, nil
+	}
+
+	if p.cache != nil && shaErr == nil {
+		if err := p.cache.put(sha, *uploadResult); err != nil {
+			p.log.Warn("failed to persist upload cache", zap.Error(err))
+		}
+	}
+
+	return uploadResult, nil
 }
 
-// DownloadAndUpload 下载在线图片并上传
+// DownloadAndUpload 下载在线图片并上传。数据直接在内存中流转：下载、压缩、
+// 上传全程不落盘，避免了逐张图片的临时文件开销
 func (p *Processor) DownloadAndUpload(url string) (*UploadResult, error) {
 	p.log.Info("downloading and uploading image", zap.String("url", url))
 
-	// 下载图片
-	tmpPath, err := wechat.DownloadFile(url)
+	reader, filename, err := wechat.OpenDownloadStream(url)
+	if err != nil {
+		return nil, fmt.Errorf("download failed: %w", err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
 	if err != nil {
 		return nil, fmt.Errorf("download failed: %w", err)
 	}
-	defer os.Remove(tmpPath)
 
-	// 检查格式
-	if !IsValidImageFormat(tmpPath) {
+	// URL 派生的文件名可能没有可靠的扩展名（CDN 常用查询参数标记格式），
+	// 所以扩展名校验失败时再用内容嗅探兜底一次
+	if !IsValidImageFormat(filename) && !IsValidImageContent(data) {
 		return nil, fmt.Errorf("downloaded file is not a valid image")
 	}
 
-	// 压缩（如果需要）
-	processedPath := tmpPath
-	if p.cfg.CompressImages {
-		compressedPath, compressed, err := p.compressor.CompressImage(tmpPath)
-		if err != nil {
+	// 压缩（如果需要），压缩本身也是内存中的 decode/encode 往返；见 isWebPFormat 的说明
+	if p.cfg.CompressImages || isWebPFormat(data) {
+		if compressed, ok, err := p.compressor.CompressBytes(data); err != nil {
 			p.log.Warn("compress failed, using original", zap.Error(err))
-		} else if compressed {
-			processedPath = compressedPath
-			defer os.Remove(compressedPath)
-			p.log.Info("using compressed image", zap.String("path", processedPath))
+		} else if ok {
+			data = compressed
+			p.log.Info("using compressed image", zap.Int("bytes", len(data)))
 		}
 	}
 
-	// 上传到微信
-	result, err := p.ws.UploadMaterialWithRetry(processedPath, 3)
+	// 文件名里的扩展名此时可能缺失（见上面的兜底校验）或者已经和压缩/转码
+	// 之后的实际格式对不上，统一用 extForImageBytes 按最终字节重新推导一次，
+	// 微信素材接口是按上传文件名的扩展名校验的
+	filename = replaceFilenameExt(filename, extForImageBytes(data, filepath.Ext(filename)))
+
+	return p.uploadBytes(data, filename)
+}
+
+// replaceFilenameExt 将 filename 的扩展名替换为 ext（含前导点）
+func replaceFilenameExt(filename, ext string) string {
+	return strings.TrimSuffix(filename, filepath.Ext(filename)) + ext
+}
+
+// UploadFromReader 直接从 reader 读取图片数据并上传，整个过程不写临时文件，
+// 适合上游已经持有内存数据（例如 HTTP 请求体）的场景
+func (p *Processor) UploadFromReader(reader io.Reader, filename string) (*UploadResult, error) {
+	p.log.Info("uploading image from reader", zap.String("filename", filename))
+
+	if !IsValidImageFormat(filename) {
+		return nil, fmt.Errorf("unsupported image format: %s", filename)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("read failed: %w", err)
+	}
+
+	// 见 isWebPFormat 的说明
+	if p.cfg.CompressImages || isWebPFormat(data) {
+		if compressed, ok, err := p.compressor.CompressBytes(data); err != nil {
+			p.log.Warn("compress failed, using original", zap.Error(err))
+		} else if ok {
+			data = compressed
+			p.log.Info("using compressed image", zap.Int("bytes", len(data)))
+		}
+	}
+
+	// 压缩/转码后扩展名可能与实际格式不符，按最终字节重新推导
+	filename = replaceFilenameExt(filename, extForImageBytes(data, filepath.Ext(filename)))
+
+	return p.uploadBytes(data, filename)
+}
+
+// uploadBytes 是 DownloadAndUpload 与 UploadFromReader 共用的缓存查询 + 上传逻辑
+func (p *Processor) uploadBytes(data []byte, filename string) (*UploadResult, error) {
+	sha := sha256Bytes(data)
+	if p.cache != nil {
+		if cached, ok := p.cache.get(sha); ok {
+			p.log.Info("upload cache hit", zap.String("sha256", sha))
+			return &cached, nil
+		}
+	}
+
+	result, err := p.ws.UploadMaterialFromReader(bytes.NewReader(data), filename)
 	if err != nil {
 		return nil, err
 	}
 
-	return &UploadResult{
+	uploadResult := &UploadResult{
 		MediaID:   result.MediaID,
 		WechatURL: result.WechatURL,
-	}, nil
+	}
+
+	if p.cache != nil {
+		if err := p.cache.put(sha, *uploadResult); err != nil {
+			p.log.Warn("failed to persist upload cache", zap.Error(err))
+		}
+	}
+
+	return uploadResult, nil
+}
+
+// InvalidateCache 从上传缓存中移除指定内容哈希对应的条目，强制下次重新上传
+func (p *Processor) InvalidateCache(sha string) error {
+	if p.cache == nil {
+		return nil
+	}
+	return p.cache.delete(sha)
+}
+
+// PurgeExpired 清理上传缓存中所有已超过有效期的条目，返回清理数量
+func (p *Processor) PurgeExpired() (int, error) {
+	if p.cache == nil {
+		return 0, nil
+	}
+	return p.cache.purgeExpired()
 }
 
 // GetImageInfo 获取图片信息
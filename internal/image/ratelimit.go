@@ -0,0 +1,58 @@
+package image
+
+import (
+	"context"
+	"net/url"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// wechatUploadHost 微信素材上传接口所在的主机，用作限流器的 key
+const wechatUploadHost = "api.weixin.qq.com"
+
+// hostRateLimiter 按主机名分别限流，避免并发上传/下载时对单一主机造成 QPS 冲击。
+// rps <= 0 表示不限流
+type hostRateLimiter struct {
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+	rps      float64
+}
+
+// newHostRateLimiter 创建限流器，rps 来自 config.Config.UploadRateLimit
+func newHostRateLimiter(rps float64) *hostRateLimiter {
+	return &hostRateLimiter{limiters: make(map[string]*rate.Limiter), rps: rps}
+}
+
+// wait 阻塞直至 host 的限流器放行或 ctx 被取消
+func (h *hostRateLimiter) wait(ctx context.Context, host string) error {
+	if h == nil || h.rps <= 0 {
+		return nil
+	}
+	return h.limiterFor(host).Wait(ctx)
+}
+
+func (h *hostRateLimiter) limiterFor(host string) *rate.Limiter {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	limiter, ok := h.limiters[host]
+	if !ok {
+		burst := int(h.rps)
+		if burst < 1 {
+			burst = 1
+		}
+		limiter = rate.NewLimiter(rate.Limit(h.rps), burst)
+		h.limiters[host] = limiter
+	}
+	return limiter
+}
+
+// hostOf 提取 URL 的主机部分，解析失败时原样返回，保证限流器总能拿到一个 key
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return u.Host
+}
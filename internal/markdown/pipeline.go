@@ -0,0 +1,87 @@
+package markdown
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/image"
+)
+
+// imgSrcPattern 匹配 <img ...> 标签中的 src 属性值，兼容单引号和双引号。
+// 捕获组 1 是 src 属性值本身在整个匹配中的位置，用于精确定位替换范围
+var imgSrcPattern = regexp.MustCompile(`<img[^>]+src=["']([^"']+)["']`)
+
+// ReplaceImages 扫描 doc 中全部 <img> 标签的 src，将其替换为上传到微信后的
+// 永久素材地址。相同的 src 只上传一次；全部待上传的图片通过
+// Processor.UploadBatch 并发上传，而不是逐张阻塞整个转换流程。
+// concurrency <= 0 时沿用 UploadBatch 的默认并发度
+func ReplaceImages(ctx context.Context, proc *image.Processor, doc string, concurrency int) (string, error) {
+	spans := imgSrcPattern.FindAllStringSubmatchIndex(doc, -1)
+	if len(spans) == 0 {
+		return doc, nil
+	}
+
+	uniqueSrcs, srcIndex := dedupeSrcs(doc, spans)
+
+	items := make([]image.BatchItem, len(uniqueSrcs))
+	for i, src := range uniqueSrcs {
+		if isRemoteURL(src) {
+			items[i] = image.BatchItem{URL: src}
+		} else {
+			items[i] = image.BatchItem{Path: src}
+		}
+	}
+
+	results := proc.UploadBatch(ctx, items, concurrency)
+
+	urls := make([]string, len(results))
+	for i, result := range results {
+		if result.Err != nil {
+			return "", fmt.Errorf("upload image %q: %w", uniqueSrcs[i], result.Err)
+		}
+		urls[i] = result.Result.WechatURL
+	}
+
+	return replaceAtSpans(doc, spans, func(src string) string {
+		return urls[srcIndex[src]]
+	}), nil
+}
+
+// dedupeSrcs 返回 doc 中每个 <img> 标签 src 的去重列表（按首次出现顺序），
+// 以及从 src 到其在列表中下标的映射，供 ReplaceImages 只上传一次重复图片
+func dedupeSrcs(doc string, spans [][]int) ([]string, map[string]int) {
+	srcIndex := make(map[string]int)
+	var uniqueSrcs []string
+	for _, span := range spans {
+		src := doc[span[2]:span[3]]
+		if _, ok := srcIndex[src]; ok {
+			continue
+		}
+		srcIndex[src] = len(uniqueSrcs)
+		uniqueSrcs = append(uniqueSrcs, src)
+	}
+	return uniqueSrcs, srcIndex
+}
+
+// replaceAtSpans 按 imgSrcPattern 匹配到的捕获组位置（spans 中每项下标为 2、3
+// 的偏移量）精确替换每个 src 属性值，避免文档中恰好出现同样文本的其他内容
+// 被误伤
+func replaceAtSpans(doc string, spans [][]int, replacement func(src string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, span := range spans {
+		start, end := span[2], span[3]
+		b.WriteString(doc[last:start])
+		b.WriteString(replacement(doc[start:end]))
+		last = end
+	}
+	b.WriteString(doc[last:])
+	return b.String()
+}
+
+// isRemoteURL 判断 src 是否为在线图片地址而非本地文件路径
+func isRemoteURL(src string) bool {
+	return strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://")
+}
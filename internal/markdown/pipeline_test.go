@@ -0,0 +1,58 @@
+package markdown
+
+import "testing"
+
+func TestDedupeSrcs(t *testing.T) {
+	doc := `<img src="a.jpg"><img src="b.jpg"><img src="a.jpg">`
+	spans := imgSrcPattern.FindAllStringSubmatchIndex(doc, -1)
+
+	unique, index := dedupeSrcs(doc, spans)
+	if want := []string{"a.jpg", "b.jpg"}; len(unique) != len(want) || unique[0] != want[0] || unique[1] != want[1] {
+		t.Fatalf("expected %v, got %v", want, unique)
+	}
+	if index["a.jpg"] != 0 || index["b.jpg"] != 1 {
+		t.Fatalf("unexpected src index: %v", index)
+	}
+}
+
+func TestReplaceAtSpans_DoesNotTouchUnrelatedText(t *testing.T) {
+	doc := `<p>see hero.jpg for reference</p><img src="hero.jpg">`
+	spans := imgSrcPattern.FindAllStringSubmatchIndex(doc, -1)
+
+	got := replaceAtSpans(doc, spans, func(src string) string {
+		return "https://mmbiz.qpic.cn/hero"
+	})
+	want := `<p>see hero.jpg for reference</p><img src="https://mmbiz.qpic.cn/hero">`
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestReplaceAtSpans_SameSrcTwice(t *testing.T) {
+	doc := `<img src="a.jpg"><img src="a.jpg">`
+	spans := imgSrcPattern.FindAllStringSubmatchIndex(doc, -1)
+
+	calls := 0
+	got := replaceAtSpans(doc, spans, func(src string) string {
+		calls++
+		return src + "#" + string(rune('0'+calls))
+	})
+	want := `<img src="a.jpg#1"><img src="a.jpg#2">`
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestIsRemoteURL(t *testing.T) {
+	cases := map[string]bool{
+		"https://example.com/a.jpg": true,
+		"http://example.com/a.jpg":  true,
+		"./local/a.jpg":             false,
+		"a.jpg":                     false,
+	}
+	for input, want := range cases {
+		if got := isRemoteURL(input); got != want {
+			t.Errorf("isRemoteURL(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
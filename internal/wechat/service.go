@@ -0,0 +1,256 @@
+package wechat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+	"go.uber.org/zap"
+)
+
+// accessTokenAPIURL 微信 access_token 获取接口地址
+const accessTokenAPIURL = "https://api.weixin.qq.com/cgi-bin/token"
+
+// materialAPIURL 微信永久素材上传接口地址
+const materialAPIURL = "https://api.weixin.qq.com/cgi-bin/material/add_material"
+
+// accessTokenExpiryMargin 提前于官方过期时间这么久就视为已过期，避免请求
+// 途中 token 恰好失效
+const accessTokenExpiryMargin = 5 * time.Minute
+
+// MaterialResult 素材上传结果
+type MaterialResult struct {
+	MediaID   string
+	WechatURL string
+}
+
+// Service 封装微信公众号素材相关接口
+type Service struct {
+	cfg            *config.Config
+	log            *zap.Logger
+	accessTokenURL string
+	materialURL    string
+	client         *http.Client
+
+	tokenMu      sync.Mutex
+	token        string
+	tokenExpires time.Time
+}
+
+// NewService 创建微信服务客户端
+func NewService(cfg *config.Config, log *zap.Logger) *Service {
+	return &Service{
+		cfg:            cfg,
+		log:            log,
+		accessTokenURL: accessTokenAPIURL,
+		materialURL:    materialAPIURL,
+		client:         &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// materialBufferPool 复用 multipart 请求体缓冲区，避免每次上传都落盘到临时文件
+var materialBufferPool = sync.Pool{
+	New: func() interface{} {
+		return bytes.NewBuffer(make([]byte, 0, 10<<20)) // 10MB
+	},
+}
+
+// UploadMaterialWithRetry 上传永久素材，失败时按次数重试
+func (s *Service) UploadMaterialWithRetry(filePath string, retries int) (*MaterialResult, error) {
+	var lastErr error
+	for attempt := 1; attempt <= retries; attempt++ {
+		result, err := s.uploadMaterialFile(filePath)
+		if err == nil {
+			return result, nil
+		}
+		lastErr = err
+		s.log.Warn("upload material failed, retrying",
+			zap.Int("attempt", attempt), zap.Error(err))
+	}
+	return nil, fmt.Errorf("upload material failed after %d attempts: %w", retries, lastErr)
+}
+
+// uploadMaterialFile 面向文件路径的瘦包装，内部委托给 UploadMaterialFromReader，
+// 仅为兼容仍然基于路径调用的场景而保留
+func (s *Service) uploadMaterialFile(filePath string) (*MaterialResult, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return s.UploadMaterialFromReader(f, filepath.Base(filePath))
+}
+
+// UploadMaterialFromReader 直接从 reader 流式读取图片数据并上传永久素材，
+// 使用 sync.Pool 复用的缓冲区拼装 multipart 请求体，避免写临时文件
+func (s *Service) UploadMaterialFromReader(reader io.Reader, filename string) (*MaterialResult, error) {
+	buf := materialBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer materialBufferPool.Put(buf)
+
+	writer := multipart.NewWriter(buf)
+	part, err := writer.CreateFormFile("media", filename)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(part, reader); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return s.postMaterial(writer.FormDataContentType(), buf.Bytes())
+}
+
+// materialAddResponse 微信素材上传接口返回的 JSON 结构。上传图片素材时
+// media_id 与 url 同时返回；errcode 非 0 表示失败
+type materialAddResponse struct {
+	MediaID string `json:"media_id"`
+	URL     string `json:"url"`
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// postMaterial 调用微信永久素材上传接口，上传类型固定为 image
+func (s *Service) postMaterial(contentType string, body []byte) (*MaterialResult, error) {
+	token, err := s.getAccessToken()
+	if err != nil {
+		return nil, fmt.Errorf("get access token: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s?access_token=%s&type=image", s.materialURL, url.QueryEscape(token))
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("wechat material upload: unexpected status %d", resp.StatusCode)
+	}
+
+	var result materialAddResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("wechat material upload: decode response: %w", err)
+	}
+	if result.ErrCode != 0 {
+		return nil, fmt.Errorf("wechat material upload failed: %d %s", result.ErrCode, result.ErrMsg)
+	}
+
+	return &MaterialResult{MediaID: result.MediaID, WechatURL: result.URL}, nil
+}
+
+// accessTokenResponse 微信 access_token 接口返回的 JSON 结构
+type accessTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+	ErrCode     int    `json:"errcode"`
+	ErrMsg      string `json:"errmsg"`
+}
+
+// getAccessToken 返回缓存的 access_token，临近过期或尚未获取时向微信重新换取。
+// 微信要求公众号自行缓存 access_token 并在过期前刷新，频繁获取会触发限流
+func (s *Service) getAccessToken() (string, error) {
+	s.tokenMu.Lock()
+	defer s.tokenMu.Unlock()
+
+	if s.token != "" && time.Now().Before(s.tokenExpires) {
+		return s.token, nil
+	}
+
+	endpoint := fmt.Sprintf("%s?grant_type=client_credential&appid=%s&secret=%s",
+		s.accessTokenURL, url.QueryEscape(s.cfg.AppID), url.QueryEscape(s.cfg.AppSecret))
+
+	resp, err := s.client.Get(endpoint)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var result accessTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("decode response: %w", err)
+	}
+	if result.ErrCode != 0 {
+		return "", fmt.Errorf("%d %s", result.ErrCode, result.ErrMsg)
+	}
+
+	s.token = result.AccessToken
+	s.tokenExpires = time.Now().Add(time.Duration(result.ExpiresIn)*time.Second - accessTokenExpiryMargin)
+	return s.token, nil
+}
+
+// DownloadFile 下载远程图片到本地临时文件，返回临时文件路径；
+// 仅为兼容仍然需要落盘文件的调用方而保留，流式场景请使用 OpenDownloadStream
+func DownloadFile(url string) (string, error) {
+	reader, filename, err := OpenDownloadStream(url)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	tmp, err := os.CreateTemp("", "md2wechat-*"+filepath.Ext(filename))
+	if err != nil {
+		return "", err
+	}
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, reader); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+
+	return tmp.Name(), nil
+}
+
+// OpenDownloadStream 以流式方式打开远程图片，调用方负责关闭返回的 ReadCloser；
+// 这是 DownloadAndUpload 在不落盘的情况下读取图片数据的基础。
+//
+// 返回的文件名取自 URL 路径部分（不含查询串/片段），因为很多 CDN 用查询参数
+// 标识格式或版本（如 .../640?wx_fmt=png），直接对完整 URL 做 filepath.Base
+// 会把查询串当成扩展名的一部分甚至丢失扩展名，导致 IsValidImageFormat 误判
+func OpenDownloadStream(rawURL string) (io.ReadCloser, string, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, "", fmt.Errorf("download failed with status %d", resp.StatusCode)
+	}
+
+	return resp.Body, filenameFromURL(rawURL), nil
+}
+
+// filenameFromURL 从 URL 中提取文件名，仅取路径部分，忽略查询串与片段；
+// 解析失败时退化为对原始字符串直接取 Base，保底返回非空文件名
+func filenameFromURL(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		if base := filepath.Base(u.Path); base != "." && base != "/" {
+			return base
+		}
+	}
+	return filepath.Base(rawURL)
+}